@@ -0,0 +1,124 @@
+package resolve
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+)
+
+// CycleError is returned by Resolve when getOrder can't make progress
+// because the graph (or the relevant part of it) contains a circular
+// dependency. Nodes lists the cycle by node name, starting and ending on the
+// same node; Types holds, for each consecutive pair of nodes, the type that
+// edge is satisfying, so callers can tell which dependency to break.
+type CycleError struct {
+	Nodes []string
+	Types []reflect.Type
+}
+
+func (e *CycleError) Error() string {
+	return "Circular dependency found: " + strings.Join(e.Nodes, " -> ")
+}
+
+// detectCycle runs a DFS over the nodes that getOrder couldn't schedule,
+// looking for a back-edge to reconstruct and report as a CycleError.
+func (r *Resolver) detectCycle(nodeDependencies map[*funcNode]map[*funcNode]bool) error {
+	visited := map[*funcNode]bool{}
+	onStack := map[*funcNode]bool{}
+	var stack []*funcNode
+
+	var visit func(n *funcNode) error
+	visit = func(n *funcNode) error {
+		visited[n] = true
+		onStack[n] = true
+		stack = append(stack, n)
+
+		for dep := range nodeDependencies[n] {
+			if onStack[dep] {
+				idx := 0
+				for i, s := range stack {
+					if s == dep {
+						idx = i
+						break
+					}
+				}
+
+				return r.buildCycleError(append(stack[idx:], dep))
+			}
+
+			if !visited[dep] {
+				if err := visit(dep); err != nil {
+					return err
+				}
+			}
+		}
+
+		stack = stack[:len(stack)-1]
+		onStack[n] = false
+
+		return nil
+	}
+
+	for n := range nodeDependencies {
+		if !visited[n] {
+			if err := visit(n); err != nil {
+				return err
+			}
+		}
+	}
+
+	// We're only called when topoSort couldn't make progress, which implies
+	// a cycle exists somewhere in the remaining graph; the DFS above should
+	// always find it.
+	return errors.New("Circular dependency found")
+}
+
+// buildCycleError turns a slice of nodes forming a cycle (first and last
+// entries are the same node) into a CycleError, annotating each edge with
+// the type it satisfies.
+func (r *Resolver) buildCycleError(cycleNodes []*funcNode) *CycleError {
+	names := make([]string, len(cycleNodes))
+	for i, n := range cycleNodes {
+		names[i] = n.name
+	}
+
+	types := make([]reflect.Type, 0, len(cycleNodes)-1)
+	for i := 0; i < len(cycleNodes)-1; i++ {
+		types = append(types, r.edgeType(cycleNodes[i], cycleNodes[i+1]))
+	}
+
+	return &CycleError{Nodes: names, Types: types}
+}
+
+// edgeType finds the type in from's requirements that is satisfied by to,
+// i.e. the dependency that makes from need to run after to.
+func (r *Resolver) edgeType(from, to *funcNode) reflect.Type {
+	for _, dep := range from.requires {
+		if members, ok := r.lookupGroupNodes(dep.t); ok {
+			for _, m := range members {
+				if m == to {
+					return dep.t
+				}
+			}
+
+			continue
+		}
+
+		var (
+			depNode *funcNode
+			ok      bool
+		)
+
+		if dep.name != "" {
+			depNode, ok = r.lookupNamedProvidedBy(dep.t, dep.name)
+		} else {
+			depNode, ok = r.lookupProvidedBy(dep.t)
+		}
+
+		if ok && depNode == to {
+			return dep.t
+		}
+	}
+
+	return nil
+}
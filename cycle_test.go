@@ -0,0 +1,30 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectCycle(t *testing.T) {
+	cyclePartOne := func(int) float32 { return 0.0 }
+	cyclePartTwo := func(float32) int { return 0 }
+
+	r := NewResolver()
+
+	err := r.AddNode("one", cyclePartOne)
+	assert.NoError(t, err)
+
+	err = r.AddNode("two", cyclePartTwo)
+	assert.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.Error(t, err, "cycle did not cause error")
+
+	cycleErr, ok := err.(*CycleError)
+	assert.True(t, ok, "error should be a *CycleError")
+
+	assert.Equal(t, 3, len(cycleErr.Nodes), "cycle should list both nodes plus the closing repeat")
+	assert.Equal(t, cycleErr.Nodes[0], cycleErr.Nodes[len(cycleErr.Nodes)-1], "cycle should start and end on the same node")
+	assert.Equal(t, 2, len(cycleErr.Types), "cycle should annotate each of its two edges with a type")
+}
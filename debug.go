@@ -0,0 +1,38 @@
+package resolve
+
+import (
+	"reflect"
+	"time"
+)
+
+// debuggingType is *Debugging's reflect.Type, used to recognize a
+// constructor's request for it without a provider ever being registered.
+var debuggingType = reflect.TypeOf((*Debugging)(nil))
+
+// Debugging is auto-provided by every Resolver: any constructor can take a
+// *Debugging parameter to inspect how the graph around it was resolved,
+// without the resolver needing to be told in advance who wants it.
+//
+// Because a constructor can only see what has already run, Debugging is
+// filled in incrementally as resolution proceeds: a constructor that runs
+// early will see a shorter Order than one that runs later. This is
+// intentional rather than a bug — Debugging describes resolution so far, not
+// the finished graph.
+type Debugging struct {
+	// Order lists the nodes that have run so far, in the order they ran.
+	Order []string
+
+	// Providers maps each provided type's string representation to the name
+	// of the node that provided it.
+	Providers map[string]string
+
+	// Elapsed records how long each node's constructor took to run.
+	Elapsed map[string]time.Duration
+}
+
+func newDebugging() *Debugging {
+	return &Debugging{
+		Providers: make(map[string]string),
+		Elapsed:   make(map[string]time.Duration),
+	}
+}
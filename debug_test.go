@@ -0,0 +1,40 @@
+package resolve
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugging(t *testing.T) {
+	r := NewResolver()
+
+	err := r.AddNode("one", func() int {
+		time.Sleep(time.Millisecond)
+		return 42
+	})
+	assert.NoError(t, err)
+
+	var early []string
+	// Requiring int makes "two" run after "one".
+	err = r.AddNode("two", func(_ int, d *Debugging) float32 {
+		early = append(early, d.Order...)
+		return 0
+	})
+	assert.NoError(t, err)
+
+	injector, err := r.Resolve()
+	assert.NoError(t, err)
+
+	var late *Debugging
+	_, err = injector.Invoke(func(d *Debugging) {
+		late = d
+	})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{"one"}, early, "a node only sees what ran before it")
+	assert.Equal(t, []string{"one", "two"}, late.Order, "later callers see the full order so far")
+	assert.Equal(t, "one", late.Providers["int"])
+	assert.True(t, late.Elapsed["one"] > 0, "elapsed time should be recorded for each node")
+}
@@ -0,0 +1,130 @@
+package resolve
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+)
+
+// GraphNode is a snapshot of a single registered node, for introspection and
+// visualization purposes.
+type GraphNode struct {
+	Name string
+
+	Provides []reflect.Type
+	Requires []reflect.Type
+
+	// ResolvedBy maps each required type's string representation to the
+	// name of the node that satisfies it. Only types satisfied by a node of
+	// this resolver get an entry: a type provided by an ancestor scope, the
+	// auto-provided *Debugging, and a type with no provider have none. For a
+	// group's slice type the entry names one of the group's members.
+	ResolvedBy map[string]string
+}
+
+// GraphEdge is a single "From needs Type, which To provides" edge.
+type GraphEdge struct {
+	From string
+	To   string
+	Type reflect.Type
+}
+
+// Graph is a point-in-time snapshot of a Resolver's nodes and the
+// dependencies between them.
+type Graph struct {
+	Nodes []GraphNode
+	Edges []GraphEdge
+
+	// Cycle is set if the graph currently contains a circular dependency,
+	// so WriteDOT can highlight it.
+	Cycle *CycleError
+}
+
+// Graph builds a snapshot of r's nodes and the edges between them, suitable
+// for inspection or rendering with WriteDOT.
+func (r *Resolver) Graph() *Graph {
+	g := &Graph{}
+
+	nodeDependencies := map[*funcNode]map[*funcNode]bool{}
+
+	for _, n := range r.nodes {
+		gn := GraphNode{
+			Name:       n.name,
+			ResolvedBy: map[string]string{},
+		}
+
+		for _, p := range n.provides {
+			gn.Provides = append(gn.Provides, p.t)
+		}
+
+		for _, dep := range n.requires {
+			gn.Requires = append(gn.Requires, dep.t)
+		}
+
+		deps, _ := r.nodeEdges(n)
+		nodeDependencies[n] = deps
+
+		for dep := range deps {
+			t := r.edgeType(n, dep)
+			gn.ResolvedBy[t.String()] = dep.name
+			g.Edges = append(g.Edges, GraphEdge{From: n.name, To: dep.name, Type: t})
+		}
+
+		g.Nodes = append(g.Nodes, gn)
+	}
+
+	if _, err := r.topoSort(nodeDependencies); err != nil {
+		if cycleErr, ok := err.(*CycleError); ok {
+			g.Cycle = cycleErr
+		}
+	}
+
+	return g
+}
+
+// WriteDOT renders g as a Graphviz "dot" digraph, with edges labeled by the
+// type they satisfy. If g.Cycle is set, the nodes and edges that form the
+// cycle are highlighted in red.
+func (g *Graph) WriteDOT(w io.Writer) error {
+	inCycle := map[string]bool{}
+	cycleEdge := map[[2]string]bool{}
+
+	if g.Cycle != nil {
+		for _, name := range g.Cycle.Nodes {
+			inCycle[name] = true
+		}
+
+		for i := 0; i < len(g.Cycle.Nodes)-1; i++ {
+			cycleEdge[[2]string{g.Cycle.Nodes[i], g.Cycle.Nodes[i+1]}] = true
+		}
+	}
+
+	if _, err := fmt.Fprintln(w, "digraph resolve {"); err != nil {
+		return err
+	}
+
+	for _, n := range g.Nodes {
+		attrs := ""
+		if inCycle[n.Name] {
+			attrs = ` [color=red,fontcolor=red,style=bold]`
+		}
+
+		if _, err := fmt.Fprintf(w, "\t%q%s;\n", n.Name, attrs); err != nil {
+			return err
+		}
+	}
+
+	for _, e := range g.Edges {
+		attrs := fmt.Sprintf(` [label=%q]`, e.Type.String())
+		if cycleEdge[[2]string{e.From, e.To}] {
+			attrs = fmt.Sprintf(` [label=%q,color=red,fontcolor=red,style=bold]`, e.Type.String())
+		}
+
+		if _, err := fmt.Fprintf(w, "\t%q -> %q%s;\n", e.From, e.To, attrs); err != nil {
+			return err
+		}
+	}
+
+	_, err := fmt.Fprintln(w, "}")
+	return err
+}
@@ -0,0 +1,49 @@
+package resolve
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraph(t *testing.T) {
+	r := NewResolver()
+
+	err := r.AddNode("one", func() int { return 42 })
+	assert.NoError(t, err)
+
+	err = r.AddNode("two", func(i int) float32 { return float32(i) })
+	assert.NoError(t, err)
+
+	g := r.Graph()
+	assert.Nil(t, g.Cycle, "acyclic graph shouldn't report a cycle")
+	assert.Equal(t, 2, len(g.Nodes))
+	assert.Equal(t, 1, len(g.Edges))
+	assert.Equal(t, "two", g.Edges[0].From)
+	assert.Equal(t, "one", g.Edges[0].To)
+
+	var buf strings.Builder
+	err = g.WriteDOT(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "digraph resolve")
+	assert.Contains(t, buf.String(), `"two" -> "one"`)
+}
+
+func TestGraphCycle(t *testing.T) {
+	r := NewResolver()
+
+	err := r.AddNode("one", func(float32) int { return 0 })
+	assert.NoError(t, err)
+
+	err = r.AddNode("two", func(int) float32 { return 0.0 })
+	assert.NoError(t, err)
+
+	g := r.Graph()
+	assert.NotNil(t, g.Cycle, "cyclic graph should report a cycle")
+
+	var buf strings.Builder
+	err = g.WriteDOT(&buf)
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), "color=red")
+}
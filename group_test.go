@@ -0,0 +1,52 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type handler struct {
+	name string
+}
+
+func TestAddGroupNode(t *testing.T) {
+	r := NewResolver()
+
+	err := r.AddGroupNode("A", "handlers", func() handler { return handler{name: "a"} })
+	assert.NoError(t, err, "unexpected error while adding group node")
+
+	err = r.AddGroupNode("B", "handlers", func() handler { return handler{name: "b"} })
+	assert.NoError(t, err, "unexpected error while adding group node")
+
+	// Adding a group node with a mismatched type should error.
+	err = r.AddGroupNode("C", "handlers", func() int { return 0 })
+	assert.Error(t, err, "no error while adding mismatched group node")
+
+	// Adding a group node that provides more than one non-error type should
+	// error.
+	err = r.AddGroupNode("D", "other", func() (handler, int) { return handler{}, 0 })
+	assert.Error(t, err, "no error while adding group node with multiple provided types")
+}
+
+func TestResolveGroup(t *testing.T) {
+	var got []handler
+
+	r := NewResolver()
+
+	err := r.AddGroupNode("A", "handlers", func() handler { return handler{name: "a"} })
+	assert.NoError(t, err)
+
+	err = r.AddGroupNode("B", "handlers", func() handler { return handler{name: "b"} })
+	assert.NoError(t, err)
+
+	err = r.AddNode("C", func(hs []handler) {
+		got = hs
+	})
+	assert.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.NoError(t, err, "unexpected error while resolving group")
+
+	assert.Equal(t, 2, len(got), "expected both group members to be collected")
+}
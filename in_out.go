@@ -0,0 +1,118 @@
+package resolve
+
+import (
+	"reflect"
+	"strings"
+)
+
+// In is embedded by a struct to mark it as a set of named and/or optional
+// dependencies, analogous to dig.In. A constructor that takes a single
+// In-embedding struct parameter has each of the struct's exported fields,
+// other than the embedded In itself, treated as an individual dependency.
+// A field may carry a `resolve:"name=foo,optional"` tag to request a value
+// registered under a name, allow the dependency to be left as its zero
+// value when no provider exists, or both.
+type In struct{}
+
+// Out is embedded by a struct returned from a constructor to mark it as a
+// set of results, analogous to dig.Out. Each exported field, other than the
+// embedded Out itself, is provided individually; a field tagged
+// `resolve:"name=foo"` is registered under that name instead of its bare
+// type, letting a single constructor provide several values of the same
+// type without colliding.
+type Out struct{}
+
+var (
+	inMarkerType  = reflect.TypeOf(In{})
+	outMarkerType = reflect.TypeOf(Out{})
+)
+
+// isInStruct reports whether t is a struct embedding In.
+func isInStruct(t reflect.Type) bool {
+	return embeds(t, inMarkerType)
+}
+
+// isOutStruct reports whether t is a struct embedding Out.
+func isOutStruct(t reflect.Type) bool {
+	return embeds(t, outMarkerType)
+}
+
+func embeds(t reflect.Type, marker reflect.Type) bool {
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type == marker {
+			return true
+		}
+	}
+
+	return false
+}
+
+// parseResolveTag parses a `resolve:"name=foo,optional"` struct tag. An
+// empty tag is valid and means "bare type, required".
+func parseResolveTag(tag string) (name string, optional bool) {
+	if tag == "" {
+		return "", false
+	}
+
+	for _, part := range strings.Split(tag, ",") {
+		switch {
+		case part == "optional":
+			optional = true
+		case strings.HasPrefix(part, "name="):
+			name = strings.TrimPrefix(part, "name=")
+		}
+	}
+
+	return name, optional
+}
+
+// inFields returns the dependency described by every exported, non-marker
+// field of an In struct, in field order.
+func inFields(t reflect.Type) []dependency {
+	var deps []dependency
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type == inMarkerType {
+			continue
+		}
+
+		if f.PkgPath != "" {
+			// Unexported; not a real dependency.
+			continue
+		}
+
+		name, optional := parseResolveTag(f.Tag.Get("resolve"))
+		deps = append(deps, dependency{t: f.Type, name: name, optional: optional})
+	}
+
+	return deps
+}
+
+// outFields returns the provided value described by every exported,
+// non-marker field of an Out struct, in field order.
+func outFields(t reflect.Type) []provided {
+	var out []provided
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type == outMarkerType {
+			continue
+		}
+
+		if f.PkgPath != "" {
+			// Unexported; not a real provided value.
+			continue
+		}
+
+		name, _ := parseResolveTag(f.Tag.Get("resolve"))
+		out = append(out, provided{t: f.Type, name: name})
+	}
+
+	return out
+}
@@ -0,0 +1,90 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type dbs struct {
+	In
+
+	Primary *int `resolve:"name=primary"`
+	Replica *int `resolve:"name=replica"`
+	Cache   *int `resolve:"optional"`
+}
+
+type dbOut struct {
+	Out
+
+	Primary *int `resolve:"name=primary"`
+	Replica *int `resolve:"name=replica"`
+}
+
+func TestInOut(t *testing.T) {
+	var got dbs
+
+	r := NewResolver()
+
+	err := r.AddNode("dbs", func() dbOut {
+		primary, replica := 1, 2
+		return dbOut{Primary: &primary, Replica: &replica}
+	})
+	assert.NoError(t, err)
+
+	err = r.AddNode("consumer", func(in dbs) {
+		got = in
+	})
+	assert.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.NoError(t, err, "unexpected error while resolving named dependencies")
+
+	assert.Equal(t, 1, *got.Primary, "primary should be wired from the named Out field")
+	assert.Equal(t, 2, *got.Replica, "replica should be wired from the named Out field")
+	assert.Nil(t, got.Cache, "optional field with no provider should be left as zero value")
+}
+
+func TestInOutIgnoresUnexportedFields(t *testing.T) {
+	type partiallyUnexported struct {
+		In
+
+		Name     string
+		internal int
+	}
+
+	r := NewResolver()
+
+	err := r.AddNode("name", func() string { return "a" })
+	assert.NoError(t, err)
+
+	err = r.AddNode("int", func() int { return 7 })
+	assert.NoError(t, err)
+
+	var got partiallyUnexported
+	err = r.AddNode("consumer", func(in partiallyUnexported) {
+		got = in
+	})
+	assert.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.NoError(t, err, "an unexported field should be ignored rather than treated as a dependency")
+	assert.Equal(t, "a", got.Name)
+	assert.Equal(t, 0, got.internal, "unexported field should be left untouched")
+}
+
+func TestInMissingRequiredIsError(t *testing.T) {
+	type required struct {
+		In
+
+		Primary *int `resolve:"name=primary"`
+	}
+
+	r := NewResolver()
+
+	err := r.AddNode("consumer", func(in required) {})
+	assert.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.Error(t, err, "a required named dependency with no provider should be a missing dependency error")
+}
@@ -0,0 +1,99 @@
+package resolve
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+
+	"github.com/codegangsta/inject"
+)
+
+// Invoke resolves only the part of the graph fn needs, then calls fn with
+// its parameters filled in from the result, without requiring fn to have
+// been registered as a node itself. Unlike Resolve, it doesn't construct the
+// whole graph for real: only fn's transitive ancestors are run by the final
+// pass, so unrelated (and possibly expensive) singletons aren't built twice
+// on every call. The collection pass that precedes it, however, is shared
+// with Resolve and still probes every registered node once to discover any
+// Collector, the same as a full Resolve would. Ancestor scopes, if any, are
+// resolved in full, not pruned. fn's parameters are filled by type alone: a
+// resolve.In struct parameter is not expanded into its fields, so calling
+// such an fn returns a missing-value error from the injector.
+func (r *Resolver) Invoke(fn interface{}) ([]reflect.Value, error) {
+	target, err := newFuncNode("", fn)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := r.collect(); err != nil {
+		return nil, err
+	}
+
+	order, err := r.prunedOrder(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var parentInjector inject.Injector
+	if r.parent != nil {
+		parentInjector, err = r.parent.Resolve()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	injector, _, err := createInjector(r, order, parentInjector)
+	if err != nil {
+		return nil, err
+	}
+
+	return injector.Invoke(target.raw)
+}
+
+// InvokeErr is a convenience wrapper around Invoke for callers who only care
+// whether fn succeeded, discarding its return values.
+func (r *Resolver) InvokeErr(fn interface{}) error {
+	_, err := r.Invoke(fn)
+	return err
+}
+
+// prunedOrder walks target's requirements back through the graph, collecting
+// only the ancestor nodes actually needed to satisfy them, and returns those
+// in topological order. It reports the same "Missing dependencies" error
+// getOrder does, but only for types that target and its ancestors require,
+// rather than for the whole graph.
+func (r *Resolver) prunedOrder(target *funcNode) ([]*funcNode, error) {
+	nodeDependencies := map[*funcNode]map[*funcNode]bool{}
+	missingDeps := map[reflect.Type]bool{}
+
+	var visit func(n *funcNode)
+	visit = func(n *funcNode) {
+		deps, missing := r.nodeEdges(n)
+
+		for _, t := range missing {
+			missingDeps[t] = true
+		}
+
+		if n != target {
+			nodeDependencies[n] = deps
+		}
+
+		for dep := range deps {
+			if _, seen := nodeDependencies[dep]; !seen {
+				visit(dep)
+			}
+		}
+	}
+
+	visit(target)
+
+	if len(missingDeps) > 0 {
+		missingDepStrs := []string{}
+		for dep := range missingDeps {
+			missingDepStrs = append(missingDepStrs, dep.String())
+		}
+		return nil, errors.New("Missing dependencies: " + strings.Join(missingDepStrs, ", "))
+	}
+
+	return r.topoSort(nodeDependencies)
+}
@@ -0,0 +1,51 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInvoke(t *testing.T) {
+	var built int
+
+	r := NewResolver()
+
+	err := r.AddNode("A", func() int { return 42 })
+	assert.NoError(t, err)
+
+	err = r.AddNode("unrelated", func() string {
+		built++
+		return "should only run once, during collection"
+	})
+	assert.NoError(t, err)
+
+	var got int
+	vals, err := r.Invoke(func(i int) string {
+		got = i
+		return "done"
+	})
+	assert.NoError(t, err, "unexpected error while invoking")
+	assert.Equal(t, 42, got, "fn should receive the resolved dependency")
+	assert.Equal(t, "done", vals[0].String(), "Invoke should return fn's raw results")
+	assert.Equal(t, 1, built, "the final pruned pass should skip unrelated constructors; only the collection pass's simulation should run them")
+}
+
+func TestInvokeMissingDependency(t *testing.T) {
+	r := NewResolver()
+
+	err := r.InvokeErr(func(i int) {})
+	assert.Error(t, err, "invoking a fn with an unsatisfiable dependency should error")
+}
+
+func TestInvokeRunsCollect(t *testing.T) {
+	r := NewResolver()
+
+	err := r.AddNode("collector", func() fakeCollector { return fakeCollector{} })
+	assert.NoError(t, err)
+
+	var got int
+	_, err = r.Invoke(func(i int) { got = i })
+	assert.NoError(t, err, "Invoke should run the collection pass before pruning, same as Resolve")
+	assert.Equal(t, 42, got, "fn should receive the value contributed by the collector")
+}
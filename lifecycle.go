@@ -0,0 +1,222 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/codegangsta/inject"
+)
+
+// Lifecycle is implemented by any value provided by a constructor that needs
+// explicit startup and shutdown. After Resolve, Start runs every instantiated
+// value implementing Lifecycle, in the same order the resolver ran their
+// constructors in; Stop runs them in reverse.
+type Lifecycle interface {
+	Start(ctx context.Context) error
+	Stop(ctx context.Context) error
+}
+
+// Collector is implemented by a value provided by a constructor that wants to
+// register additional constructors with the resolver once it has been
+// instantiated. Collects is called at most once per node over the resolver's
+// lifetime, during the collection pass of the first Resolve, Start or Invoke
+// that instantiates it; that pass runs before the final topological sort, so
+// any constructors it returns are treated exactly as if they had been passed
+// to AddNode up front.
+type Collector interface {
+	Collects() []interface{}
+}
+
+// collect runs a best-effort simulation of the graph as it currently stands,
+// instantiating whatever nodes it can regardless of whether the whole graph
+// resolves yet, and adds any constructors returned by a Collector's Collects
+// method to the resolver. It repeats until a pass collects nothing new, so a
+// collected constructor that is itself a Collector (or that unblocks a node
+// leading to one) is picked up too, all before the real getOrder/createInjector
+// pass that follows.
+func (r *Resolver) collect() error {
+	for {
+		resolved := map[*funcNode]bool{}
+		injector := inject.New()
+		namedValues := map[reflect.Type]map[string]reflect.Value{}
+		groupValues := map[string][]reflect.Value{}
+		added := false
+
+		for progress := true; progress; {
+			progress = false
+
+			for _, n := range r.nodes {
+				if resolved[n] || !r.collectReady(n, resolved) {
+					continue
+				}
+
+				// Use the same invocation path createInjector uses, so a
+				// node taking a resolve.In-struct parameter or a group
+				// slice parameter can be invoked here too, not just during
+				// the real pass that follows.
+				vals, err := invokeNode(injector, namedValues, n)
+				if err != nil {
+					// Not actually invocable yet (e.g. a dependency only
+					// the real injector, not this simulation, can supply).
+					continue
+				}
+
+				resolved[n] = true
+				progress = true
+
+				for _, v := range vals {
+					if _, ok := v.Interface().(error); ok {
+						continue
+					}
+
+					if n.group != "" && v.Type() == r.groupItemType[n.group] {
+						groupValues[n.group] = append(groupValues[n.group], v)
+
+						sliceType := reflect.SliceOf(v.Type())
+						slice := reflect.MakeSlice(sliceType, 0, len(groupValues[n.group]))
+						slice = reflect.Append(slice, groupValues[n.group]...)
+
+						injector.Set(sliceType, slice)
+
+						continue
+					}
+
+					if n.outType != nil && v.Type() == n.outType {
+						var fields []reflect.Value
+						unpackOutStruct(injector, namedValues, v, &fields)
+					} else {
+						injector.Set(v.Type(), v)
+					}
+
+					if r.collected[n] {
+						continue
+					}
+
+					c, ok := v.Interface().(Collector)
+					if !ok {
+						continue
+					}
+
+					r.collected[n] = true
+
+					for _, ctor := range c.Collects() {
+						name := fmt.Sprintf("__collected_%d", len(r.nodes))
+						if err := r.AddNode(name, ctor); err != nil {
+							return err
+						}
+
+						added = true
+					}
+				}
+			}
+		}
+
+		if !added {
+			return nil
+		}
+	}
+}
+
+// collectReady reports whether every type n requires has already been
+// instantiated in this simulation (or is provided by an ancestor scope,
+// which is assumed to already be resolvable). It mirrors the dependency
+// checks in getOrder, but treats an unsatisfiable requirement as "not ready
+// yet" instead of an error, since the collection pass runs before the
+// resolver knows its full set of nodes. Unlike getOrder, it counts a
+// *Debugging requirement as unsatisfied, so a node that takes *Debugging is
+// never run by the collection pass.
+func (r *Resolver) collectReady(n *funcNode, resolved map[*funcNode]bool) bool {
+	for _, dep := range n.requires {
+		if members, ok := r.lookupGroupNodes(dep.t); ok {
+			if _, local := r.groupSliceType[dep.t]; local {
+				for _, m := range members {
+					if !resolved[m] {
+						return false
+					}
+				}
+			}
+
+			continue
+		}
+
+		var (
+			depNode *funcNode
+			ok      bool
+			local   bool
+		)
+
+		if dep.name != "" {
+			depNode, ok = r.lookupNamedProvidedBy(dep.t, dep.name)
+			_, local = r.providedByNamed[dep.t][dep.name]
+		} else {
+			depNode, ok = r.lookupProvidedBy(dep.t)
+			_, local = r.providedBy[dep.t]
+		}
+
+		if !ok {
+			if dep.optional {
+				continue
+			}
+
+			return false
+		}
+
+		if local && !resolved[depNode] {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Start resolves the graph, then starts every instantiated value
+// implementing Lifecycle, in resolution order. If a Start call fails, the
+// values already started are left running; the caller should still call Stop
+// to unwind them.
+func (r *Resolver) Start(ctx context.Context) error {
+	_, values, err := r.resolve()
+	if err != nil {
+		return err
+	}
+
+	r.started = nil
+
+	for _, v := range values {
+		lc, ok := v.Interface().(Lifecycle)
+		if !ok {
+			continue
+		}
+
+		if err := lc.Start(ctx); err != nil {
+			return err
+		}
+
+		r.started = append(r.started, lc)
+	}
+
+	return nil
+}
+
+// Stop shuts down every value started by Start, in the reverse of the order
+// they were started in. Every Stop is run even if an earlier one fails, and
+// any resulting errors are aggregated into a single error.
+func (r *Resolver) Stop(ctx context.Context) error {
+	var errs []string
+
+	for i := len(r.started) - 1; i >= 0; i-- {
+		if err := r.started[i].Stop(ctx); err != nil {
+			errs = append(errs, err.Error())
+		}
+	}
+
+	r.started = nil
+
+	if len(errs) > 0 {
+		return errors.New("Errors while stopping: " + strings.Join(errs, "; "))
+	}
+
+	return nil
+}
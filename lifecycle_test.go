@@ -0,0 +1,158 @@
+package resolve
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeService struct {
+	name     string
+	events   *[]string
+	startErr error
+	stopErr  error
+}
+
+func (s *fakeService) Start(ctx context.Context) error {
+	*s.events = append(*s.events, "start:"+s.name)
+	return s.startErr
+}
+
+func (s *fakeService) Stop(ctx context.Context) error {
+	*s.events = append(*s.events, "stop:"+s.name)
+	return s.stopErr
+}
+
+// fakeServiceB wraps a *fakeService under a distinct type, so a node that
+// depends on *fakeService (to force ordering) can still provide its own
+// Lifecycle value without conflicting with the node that provides
+// *fakeService itself.
+type fakeServiceB struct {
+	*fakeService
+}
+
+func TestStartStop(t *testing.T) {
+	var events []string
+
+	r := NewResolver()
+
+	err := r.AddNode("A", func() *fakeService {
+		return &fakeService{name: "a", events: &events}
+	})
+	assert.NoError(t, err)
+
+	err = r.AddNode("B", func(a *fakeService) *fakeServiceB {
+		return &fakeServiceB{fakeService: &fakeService{name: "b", events: &events}}
+	})
+	assert.NoError(t, err)
+
+	err = r.Start(context.Background())
+	assert.NoError(t, err, "unexpected error while starting")
+
+	assert.Equal(t, []string{"start:a", "start:b"}, events, "services should start in resolution order")
+
+	err = r.Stop(context.Background())
+	assert.NoError(t, err, "unexpected error while stopping")
+
+	assert.Equal(t, []string{"start:a", "start:b", "stop:b", "stop:a"}, events, "services should stop in reverse order")
+}
+
+func TestStopAggregatesErrors(t *testing.T) {
+	var events []string
+
+	r := NewResolver()
+
+	err := r.AddNode("A", func() *fakeService {
+		return &fakeService{name: "a", events: &events, stopErr: errors.New("boom")}
+	})
+	assert.NoError(t, err)
+
+	err = r.Start(context.Background())
+	assert.NoError(t, err)
+
+	err = r.Stop(context.Background())
+	assert.Error(t, err, "stop should surface errors from stopped services")
+}
+
+type fakeCollector struct{}
+
+func (fakeCollector) Collects() []interface{} {
+	return []interface{}{func() int { return 42 }}
+}
+
+func TestCollect(t *testing.T) {
+	var got int
+
+	r := NewResolver()
+
+	err := r.AddNode("A", func() fakeCollector { return fakeCollector{} })
+	assert.NoError(t, err)
+
+	err = r.AddNode("B", func(i int) { got = i })
+	assert.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.NoError(t, err, "collected constructor should satisfy dependent node")
+	assert.Equal(t, 42, got)
+}
+
+type collectorIn struct {
+	In
+
+	Name string
+}
+
+func (collectorIn) Collects() []interface{} {
+	return []interface{}{func() int { return 42 }}
+}
+
+func TestCollectWithInStruct(t *testing.T) {
+	var got int
+
+	r := NewResolver()
+
+	err := r.AddNode("name", func() string { return "a" })
+	assert.NoError(t, err)
+
+	err = r.AddNode("A", func(in collectorIn) collectorIn { return in })
+	assert.NoError(t, err)
+
+	err = r.AddNode("B", func(i int) { got = i })
+	assert.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.NoError(t, err, "collect should be able to invoke a Collector that takes a resolve.In-struct parameter")
+	assert.Equal(t, 42, got)
+}
+
+type collectorGroup struct {
+	handlers []handler
+}
+
+func (c collectorGroup) Collects() []interface{} {
+	return []interface{}{func() int { return len(c.handlers) }}
+}
+
+func TestCollectWithGroupSlice(t *testing.T) {
+	var got int
+
+	r := NewResolver()
+
+	err := r.AddGroupNode("A", "handlers", func() handler { return handler{name: "a"} })
+	assert.NoError(t, err)
+
+	err = r.AddGroupNode("B", "handlers", func() handler { return handler{name: "b"} })
+	assert.NoError(t, err)
+
+	err = r.AddNode("collector", func(hs []handler) collectorGroup { return collectorGroup{handlers: hs} })
+	assert.NoError(t, err)
+
+	err = r.AddNode("consumer", func(i int) { got = i })
+	assert.NoError(t, err)
+
+	_, err = r.Resolve()
+	assert.NoError(t, err, "collect should be able to invoke a Collector that takes a group slice parameter")
+	assert.Equal(t, 2, got, "collector should have seen both group members")
+}
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"reflect"
 	"strings"
+	"time"
 
 	"github.com/codegangsta/inject"
 )
@@ -33,13 +34,47 @@ func EnsureValidFactory(item interface{}) error {
 	return nil
 }
 
+// dependency describes a single required type. A zero-value name means the
+// bare type is wanted, the way AddNode parameters always behave; a non-empty
+// name means a value registered under that name via an Out-struct field is
+// wanted instead. An optional dependency that can't be satisfied is left as
+// the type's zero value rather than causing a "Missing dependencies" error.
+type dependency struct {
+	t        reflect.Type
+	name     string
+	optional bool
+}
+
+// provided describes a single value a node can supply. A zero-value name
+// means the value is registered under its bare type; a non-empty name means
+// it was provided by way of a named Out-struct field and must be requested
+// through a matching In-struct field instead.
+type provided struct {
+	t    reflect.Type
+	name string
+}
+
 type funcNode struct {
 	name string
 
-	provides []reflect.Type
-	requires []reflect.Type
+	provides []provided
+	requires []dependency
 
 	raw interface{}
+
+	// group is non-empty when this node was registered with AddGroupNode and
+	// names the group its single provided value belongs to.
+	group string
+
+	// inType is set when item takes a single resolve.In-embedding struct
+	// parameter, in which case requires describes that struct's fields
+	// rather than item's raw parameter list.
+	inType reflect.Type
+
+	// outType is set when item returns a resolve.Out-embedding struct, in
+	// which case provides describes that struct's fields rather than item's
+	// raw return type.
+	outType reflect.Type
 }
 
 func newFuncNode(name string, item interface{}) (*funcNode, error) {
@@ -57,14 +92,29 @@ func newFuncNode(name string, item interface{}) (*funcNode, error) {
 	// EnsureValidFactory, so we don't need to do it again here.
 	t := reflect.TypeOf(item)
 
-	// Grab all the provided args
+	// Grab all the provided args, expanding a single resolve.Out-embedding
+	// return struct into its individual fields.
 	for i := 0; i < t.NumOut(); i++ {
-		n.provides = append(n.provides, t.Out(i))
+		ot := t.Out(i)
+
+		if !ot.Implements(errorType) && isOutStruct(ot) {
+			n.outType = ot
+			n.provides = append(n.provides, outFields(ot)...)
+			continue
+		}
+
+		n.provides = append(n.provides, provided{t: ot})
 	}
 
-	// Grab all the incoming args
-	for i := 0; i < t.NumIn(); i++ {
-		n.requires = append(n.requires, t.In(i))
+	// Grab all the incoming args, expanding a single resolve.In-embedding
+	// parameter into its individual fields.
+	if t.NumIn() == 1 && isInStruct(t.In(0)) {
+		n.inType = t.In(0)
+		n.requires = inFields(n.inType)
+	} else {
+		for i := 0; i < t.NumIn(); i++ {
+			n.requires = append(n.requires, dependency{t: t.In(i)})
+		}
 	}
 
 	return n, nil
@@ -76,15 +126,96 @@ type Resolver struct {
 	nodes      []*funcNode
 	names      map[string]bool
 	providedBy map[reflect.Type]*funcNode
+
+	// providedByNamed holds nodes that provide a type under a name, via an
+	// Out-struct field, keyed first by type and then by name.
+	providedByNamed map[reflect.Type]map[string]*funcNode
+
+	// parent is non-nil for scopes created with Scope. A node registered on
+	// a child is invisible to the parent and to sibling scopes, but the
+	// child can still depend on anything the parent (or one of its
+	// ancestors) provides.
+	parent *Resolver
+
+	// name identifies a scope created with Scope. The root resolver has an
+	// empty name.
+	name string
+
+	// groupItemType and groupNodes track the constructors registered with
+	// AddGroupNode, keyed by group name. groupSliceType maps the
+	// aggregated slice type back to the group name so getOrder can
+	// recognize a dependency on a group.
+	groupItemType  map[string]reflect.Type
+	groupNodes     map[string][]*funcNode
+	groupSliceType map[reflect.Type]string
+
+	// started holds the Lifecycle values started by the most recent call to
+	// Start, in start order, so Stop can unwind them in reverse.
+	started []Lifecycle
+
+	// collected tracks which nodes have already had Collects called on
+	// their instantiated value, so a node contributed during a collection
+	// pass isn't re-added (and re-conflicting with itself) on a later
+	// Resolve or Start call.
+	collected map[*funcNode]bool
 }
 
 // NewResolver returns an empty resolve set which can be used for resolving
 // function calls.
 func NewResolver() *Resolver {
 	return &Resolver{
-		names:      make(map[string]bool),
-		providedBy: make(map[reflect.Type]*funcNode),
+		names:           make(map[string]bool),
+		providedBy:      make(map[reflect.Type]*funcNode),
+		providedByNamed: make(map[reflect.Type]map[string]*funcNode),
+		groupItemType:   make(map[string]reflect.Type),
+		groupNodes:      make(map[string][]*funcNode),
+		groupSliceType:  make(map[reflect.Type]string),
+		collected:       make(map[*funcNode]bool),
+	}
+}
+
+// lookupNamedProvidedBy returns the node which provides t under name,
+// searching this resolver's own nodes before walking up the parent chain.
+func (r *Resolver) lookupNamedProvidedBy(t reflect.Type, name string) (*funcNode, bool) {
+	if n, ok := r.providedByNamed[t][name]; ok {
+		return n, true
 	}
+
+	if r.parent != nil {
+		return r.parent.lookupNamedProvidedBy(t, name)
+	}
+
+	return nil, false
+}
+
+// lookupGroupNodes returns the constructors registered under the group whose
+// aggregated slice type is t, searching this resolver before walking up the
+// parent chain.
+func (r *Resolver) lookupGroupNodes(t reflect.Type) ([]*funcNode, bool) {
+	if group, ok := r.groupSliceType[t]; ok {
+		return r.groupNodes[group], true
+	}
+
+	if r.parent != nil {
+		return r.parent.lookupGroupNodes(t)
+	}
+
+	return nil, false
+}
+
+// lookupProvidedBy returns the node which provides t, searching this
+// resolver's own nodes before walking up the parent chain. The bool return
+// reports whether a provider was found anywhere in the chain.
+func (r *Resolver) lookupProvidedBy(t reflect.Type) (*funcNode, bool) {
+	if n, ok := r.providedBy[t]; ok {
+		return n, true
+	}
+
+	if r.parent != nil {
+		return r.parent.lookupProvidedBy(t)
+	}
+
+	return nil, false
 }
 
 // AddNode adds a function to an internal graph of dependencies. The resolution
@@ -100,18 +231,32 @@ func (r *Resolver) AddNode(name string, item interface{}) error {
 	}
 
 	// Ensure there are not overlapping provided types
-	for _, t := range n.provides {
+	for _, p := range n.provides {
 		// We don't care if multiple functions return errors, or even if
 		// multiple errors are returned from a single constructor.
-		if t.Implements(errorType) {
+		if p.t.Implements(errorType) {
+			continue
+		}
+
+		if p.name != "" {
+			if _, ok := r.providedByNamed[p.t][p.name]; ok {
+				return errors.New("Named type provided by multiple constructors")
+			}
+
+			if r.providedByNamed[p.t] == nil {
+				r.providedByNamed[p.t] = make(map[string]*funcNode)
+			}
+
+			r.providedByNamed[p.t][p.name] = n
+
 			continue
 		}
 
-		if _, ok := r.providedBy[t]; ok {
+		if _, ok := r.providedBy[p.t]; ok {
 			return errors.New("Type provided by multiple constructors")
 		}
 
-		r.providedBy[t] = n
+		r.providedBy[p.t] = n
 	}
 
 	// Now that we have a valid node, we need to save it for later.
@@ -121,6 +266,58 @@ func (r *Resolver) AddNode(name string, item interface{}) error {
 	return nil
 }
 
+// AddGroupNode adds a function to the internal graph of dependencies, same as
+// AddNode, but marks its single non-error provided value as a member of the
+// named group instead of registering it as a regular, individually-resolvable
+// type. Any number of constructors may contribute to the same group as long
+// as they all provide the same type. A consumer can request every value
+// contributed to the group by declaring a parameter of the corresponding
+// slice type, e.g. constructors providing `Handler` under group "handlers"
+// can be consumed together via a parameter of type `[]Handler`.
+func (r *Resolver) AddGroupNode(name, group string, item interface{}) error {
+	if r.names[name] {
+		return errors.New("Name provided by multiple nodes")
+	}
+
+	n, err := newFuncNode(name, item)
+	if err != nil {
+		return err
+	}
+
+	var itemType reflect.Type
+	count := 0
+
+	for _, p := range n.provides {
+		if p.t.Implements(errorType) {
+			continue
+		}
+
+		itemType = p.t
+		count++
+	}
+
+	if count != 1 {
+		return errors.New("Group node must provide exactly one non-error type")
+	}
+
+	if existing, ok := r.groupItemType[group]; ok {
+		if existing != itemType {
+			return errors.New("Group provided by constructors with mismatched types")
+		}
+	} else {
+		r.groupItemType[group] = itemType
+		r.groupSliceType[reflect.SliceOf(itemType)] = group
+	}
+
+	n.group = group
+
+	r.groupNodes[group] = append(r.groupNodes[group], n)
+	r.nodes = append(r.nodes, n)
+	r.names[name] = true
+
+	return nil
+}
+
 // Resolve will walk the graph of constructor nodes, run the constructors in the
 // order they need to be run, and return an injector with all the return values
 // from these constructors. Any error returned by these constructors will be
@@ -129,30 +326,115 @@ func (r *Resolver) AddNode(name string, item interface{}) error {
 // recommended to not use this often. Additionally, all nodes must be added
 // before this method is called.
 func (r *Resolver) Resolve() (inject.Injector, error) {
-	order, err := r.getOrder()
+	injector, _, err := r.resolve()
 	if err != nil {
 		return nil, err
 	}
 
-	return createInjector(order)
+	return injector, nil
+}
+
+// resolve runs the collection pass, computes the topo order and builds the
+// injector, returning the flat list of instantiated, non-error values in
+// resolution order alongside it. It's shared by Resolve and the lifecycle
+// methods, which both need access to the instantiated values rather than
+// just the injector.
+//
+// A scope can see everything its ancestors provide, so the parent chain is
+// resolved and attached before this resolver's own nodes are invoked: a node
+// that requires a parent-provided type must be able to see it immediately,
+// not only after the child injector is handed back to the caller.
+func (r *Resolver) resolve() (inject.Injector, []reflect.Value, error) {
+	if err := r.collect(); err != nil {
+		return nil, nil, err
+	}
+
+	order, err := r.getOrder()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var parentInjector inject.Injector
+	if r.parent != nil {
+		parentInjector, err = r.parent.Resolve()
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return createInjector(r, order, parentInjector)
+}
+
+// nodeEdges computes, for a single node, the local ancestor nodes it
+// depends on (the ones this resolver itself would need to run first) and
+// the types it requires that have no provider anywhere in the scope chain.
+// A missing optional dependency is simply omitted rather than reported.
+func (r *Resolver) nodeEdges(n *funcNode) (deps map[*funcNode]bool, missing []reflect.Type) {
+	deps = map[*funcNode]bool{}
+
+	for _, dep := range n.requires {
+		// *Debugging is auto-provided by createInjector itself, not by any
+		// registered node, so it never has edges and is never missing.
+		if dep.t == debuggingType {
+			continue
+		}
+
+		// A requirement on a group's slice type is satisfied by every
+		// member of that group, rather than a single node.
+		if members, ok := r.lookupGroupNodes(dep.t); ok {
+			if _, local := r.groupSliceType[dep.t]; local {
+				for _, m := range members {
+					deps[m] = true
+				}
+			}
+			continue
+		}
+
+		var (
+			depNode *funcNode
+			ok      bool
+			local   bool
+		)
+
+		if dep.name != "" {
+			depNode, ok = r.lookupNamedProvidedBy(dep.t, dep.name)
+			_, local = r.providedByNamed[dep.t][dep.name]
+		} else {
+			depNode, ok = r.lookupProvidedBy(dep.t)
+			_, local = r.providedBy[dep.t]
+		}
+
+		if !ok {
+			if !dep.optional {
+				missing = append(missing, dep.t)
+			}
+			continue
+		}
+
+		if !local {
+			// Provided by an ancestor scope, not this one.
+			continue
+		}
+
+		deps[depNode] = true
+	}
+
+	return deps, missing
 }
 
 func (r *Resolver) getOrder() ([]*funcNode, error) {
 	nodeDependencies := map[*funcNode]map[*funcNode]bool{}
 	missingDeps := map[reflect.Type]bool{}
 
-	// Loop over all nodes and add edges for all requirements
+	// Loop over all nodes and add edges for all requirements. Requirements
+	// satisfied by an ancestor scope are already resolved by the time this
+	// scope runs, so they don't participate in this scope's topo sort.
 	for _, n := range r.nodes {
-		nodeDependencies[n] = make(map[*funcNode]bool)
+		deps, missing := r.nodeEdges(n)
+		nodeDependencies[n] = deps
 
-		for _, t := range n.requires {
-			depNode, ok := r.providedBy[t]
-			if !ok {
-				missingDeps[t] = true
-				continue
-			}
-
-			nodeDependencies[n][depNode] = true
+		for _, t := range missing {
+			missingDeps[t] = true
 		}
 	}
 
@@ -164,6 +446,13 @@ func (r *Resolver) getOrder() ([]*funcNode, error) {
 		return nil, errors.New("Missing dependencies: " + strings.Join(missingDepStrs, ", "))
 	}
 
+	return r.topoSort(nodeDependencies)
+}
+
+// topoSort runs Kahn's algorithm over a dependency graph built by getOrder
+// or prunedOrder, returning the nodes in an order where every dependency
+// comes before the nodes that need it.
+func (r *Resolver) topoSort(nodeDependencies map[*funcNode]map[*funcNode]bool) ([]*funcNode, error) {
 	var order []*funcNode
 
 	// Loop through nodeDependencies as long as there are any left
@@ -180,8 +469,7 @@ func (r *Resolver) getOrder() ([]*funcNode, error) {
 
 		// If there are no ready nodes, we have a circular dependency
 		if len(ready) == 0 {
-			// TODO: Display the nodes in the cycle
-			return nil, errors.New("Circular dependency found")
+			return nil, r.detectCycle(nodeDependencies)
 		}
 
 		for _, node := range ready {
@@ -201,24 +489,85 @@ func (r *Resolver) getOrder() ([]*funcNode, error) {
 	return order, nil
 }
 
-func createInjector(order []*funcNode) (inject.Injector, error) {
+func createInjector(r *Resolver, order []*funcNode, parentInjector inject.Injector) (inject.Injector, []reflect.Value, error) {
 	// Create a new injector for returning
 	injector := inject.New()
 
+	// The parent must be attached before any of this resolver's own nodes
+	// are invoked below, since a node may require a type only the parent
+	// (or one of its ancestors) provides.
+	if parentInjector != nil {
+		injector.SetParent(parentInjector)
+	}
+
+	// groupValues accumulates the values contributed by each group's members
+	// as they're invoked, so the aggregated slice can be kept up to date on
+	// the injector for any consumer scheduled after them.
+	groupValues := map[string][]reflect.Value{}
+
+	// namedValues holds values provided under a name via an Out-struct
+	// field, keyed by type and then name. The plain injector has no concept
+	// of a name, so these live alongside it instead of inside it.
+	namedValues := map[reflect.Type]map[string]reflect.Value{}
+
+	// values collects every non-error value produced, in resolution order,
+	// for callers that need to inspect the instantiated values themselves
+	// (the lifecycle methods).
+	var values []reflect.Value
+
+	// debugging is auto-provided to any constructor that asks for it; it's
+	// updated as we go, so a constructor only ever sees what ran before it.
+	debugging := newDebugging()
+	injector.Set(debuggingType, reflect.ValueOf(debugging))
+
 	// For each node, we need to call it, then add the returned values to the
 	// injector.
 	for _, n := range order {
-		vals, err := injector.Invoke(n.raw)
+		start := time.Now()
+
+		vals, err := invokeNode(injector, namedValues, n)
 		if err != nil {
 			// Note that this shouldn't be possible to hit because we already
 			// ensured there are no missing deps above.
-			return nil, err
+			return nil, nil, err
+		}
+
+		debugging.Order = append(debugging.Order, n.name)
+		debugging.Elapsed[n.name] = time.Since(start)
+
+		for _, p := range n.provides {
+			if !p.t.Implements(errorType) {
+				debugging.Providers[p.t.String()] = n.name
+			}
 		}
 
 		for _, v := range vals {
 			// If we got a non-nil error, we need to return it.
-			if err, ok := v.Interface().(error); ok && err != nil {
-				return nil, err
+			if err, ok := v.Interface().(error); ok {
+				if err != nil {
+					return nil, nil, err
+				}
+
+				continue
+			}
+
+			if n.outType != nil && v.Type() == n.outType {
+				unpackOutStruct(injector, namedValues, v, &values)
+				continue
+			}
+
+			values = append(values, v)
+
+			if n.group != "" && v.Type() == r.groupItemType[n.group] {
+				groupValues[n.group] = append(groupValues[n.group], v)
+
+				sliceType := reflect.SliceOf(v.Type())
+				slice := reflect.MakeSlice(sliceType, 0, len(groupValues[n.group]))
+				slice = reflect.Append(slice, groupValues[n.group]...)
+
+				injector.Set(sliceType, slice)
+
+				continue
 			}
 
 			// Add any non-error types to the injector.
@@ -226,5 +575,81 @@ func createInjector(order []*funcNode) (inject.Injector, error) {
 		}
 	}
 
-	return injector, nil
+	return injector, values, nil
+}
+
+// invokeNode calls n's constructor and returns its raw results. A node whose
+// requires came from a resolve.In struct can't be filled by the plain
+// injector, since it knows nothing about names or optional fields, so its
+// argument struct is built by hand instead.
+func invokeNode(injector inject.Injector, namedValues map[reflect.Type]map[string]reflect.Value, n *funcNode) ([]reflect.Value, error) {
+	if n.inType == nil {
+		return injector.Invoke(n.raw)
+	}
+
+	arg := reflect.New(n.inType).Elem()
+	depIdx := 0
+
+	for i := 0; i < n.inType.NumField(); i++ {
+		f := n.inType.Field(i)
+		if f.Anonymous && f.Type == inMarkerType {
+			continue
+		}
+
+		if f.PkgPath != "" {
+			// Unexported; inFields didn't count it as a dependency either.
+			continue
+		}
+
+		dep := n.requires[depIdx]
+		depIdx++
+
+		val := namedValues[dep.t][dep.name]
+		if dep.name == "" {
+			val = injector.Get(dep.t)
+		}
+
+		if !val.IsValid() {
+			val = reflect.Zero(dep.t)
+		}
+
+		arg.Field(i).Set(val)
+	}
+
+	return reflect.ValueOf(n.raw).Call([]reflect.Value{arg}), nil
+}
+
+// unpackOutStruct splits a resolve.Out-embedding return value into its
+// individual fields, storing named fields in namedValues and bare fields on
+// the injector, and appends each field value to values in field order.
+func unpackOutStruct(injector inject.Injector, namedValues map[reflect.Type]map[string]reflect.Value, out reflect.Value, values *[]reflect.Value) {
+	t := out.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous && f.Type == outMarkerType {
+			continue
+		}
+
+		if f.PkgPath != "" {
+			// Unexported; outFields didn't count it as a provided value
+			// either.
+			continue
+		}
+
+		fv := out.Field(i)
+		*values = append(*values, fv)
+
+		name, _ := parseResolveTag(f.Tag.Get("resolve"))
+		if name == "" {
+			injector.Set(fv.Type(), fv)
+			continue
+		}
+
+		if namedValues[fv.Type()] == nil {
+			namedValues[fv.Type()] = make(map[string]reflect.Value)
+		}
+
+		namedValues[fv.Type()][name] = fv
+	}
 }
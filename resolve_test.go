@@ -32,7 +32,7 @@ func TestNode(t *testing.T) {
 
 	assert.Equal(t, len(n.provides), 0, "number of provided types should be 0")
 	assert.Equal(t, len(n.requires), 1, "number of required types should be 1")
-	assert.Equal(t, n.requires[0].Kind(), reflect.Int, "required type should be Int")
+	assert.Equal(t, n.requires[0].t.Kind(), reflect.Int, "required type should be Int")
 
 	// Ensure the types match when given one return value
 	n, err = newFuncNode("A", func() int { return 0 })
@@ -40,7 +40,7 @@ func TestNode(t *testing.T) {
 
 	assert.Equal(t, len(n.provides), 1, "number of provided types should be 1")
 	assert.Equal(t, len(n.requires), 0, "number of required types should be 0")
-	assert.Equal(t, n.provides[0].Kind(), reflect.Int, "provided type should be Int")
+	assert.Equal(t, n.provides[0].t.Kind(), reflect.Int, "provided type should be Int")
 }
 
 func TestAddNode(t *testing.T) {
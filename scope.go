@@ -0,0 +1,16 @@
+package resolve
+
+// Scope returns a new child Resolver. The child can depend on any type
+// provided by this resolver or any of its ancestors, but nodes registered on
+// the child are only visible within the child itself: they neither shadow
+// nor leak into the parent or sibling scopes.
+//
+// The name is purely descriptive; it is stored on the child but not used to
+// disambiguate scopes, nor shown in errors or debugging output.
+func (r *Resolver) Scope(name string) *Resolver {
+	child := NewResolver()
+	child.name = name
+	child.parent = r
+
+	return child
+}
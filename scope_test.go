@@ -0,0 +1,49 @@
+package resolve
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestScope(t *testing.T) {
+	providesInt := func() int { return 42 }
+
+	parent := NewResolver()
+	err := parent.AddNode("A", providesInt)
+	assert.NoError(t, err, "unexpected error while adding node")
+
+	child := parent.Scope("child")
+
+	// The child can depend on a type provided by the parent.
+	err = child.AddNode("B", func(i int) string { return "" })
+	assert.NoError(t, err, "unexpected error while adding node")
+
+	_, err = child.Resolve()
+	assert.NoError(t, err, "child should be able to resolve parent-provided types")
+
+	// A type registered on the child is invisible to the parent.
+	err = child.AddNode("C", func() bool { return true })
+	assert.NoError(t, err, "unexpected error while adding node")
+
+	_, err = parent.Resolve()
+	assert.NoError(t, err, "parent resolve should be unaffected by child registrations")
+
+	// A child may shadow a type already provided by its parent.
+	grandchild := parent.Scope("grandchild")
+	err = grandchild.AddNode("D", func() int { return 7 })
+	assert.NoError(t, err, "child should be able to shadow a parent-provided type")
+}
+
+func TestScopeInvoke(t *testing.T) {
+	parent := NewResolver()
+	err := parent.AddNode("A", func() int { return 42 })
+	assert.NoError(t, err, "unexpected error while adding node")
+
+	child := parent.Scope("child")
+
+	var got int
+	_, err = child.Invoke(func(i int) { got = i })
+	assert.NoError(t, err, "Invoke should be able to resolve parent-provided types")
+	assert.Equal(t, 42, got, "fn should receive the parent-provided dependency")
+}